@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// checkCmd exits 0 if every given IP address is in scope, 1 otherwise, so
+// it can be used directly in scripts.
+var checkCmd = &cobra.Command{
+	Use:   "check <ip>...",
+	Short: "Check whether all given IP addresses are in scope",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		allInScope := true
+
+		for _, a := range args {
+			ip := net.ParseIP(a)
+			if ip == nil {
+				return fmt.Errorf("invalid IP address: %q", a)
+			}
+
+			inScope := scope.Contains(ip) && !exclude.Contains(ip)
+			if !inScope {
+				allInScope = false
+			}
+
+			fmt.Printf("%s: %t\n", ip, inScope)
+		}
+
+		if !allInScope {
+			os.Exit(1)
+		}
+
+		return nil
+	},
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// completionCmd generates shell completion scripts for scoper. It replaces
+// cobra's default "completion" command so the help text can document how
+// scoper is actually invoked.
+var completionCmd = &cobra.Command{
+	Use:   "completion [bash|zsh|fish|powershell]",
+	Short: "Generate shell completion script",
+	Long: `To load completions:
+
+Bash:
+  $ source <(scoper completion bash)
+
+Zsh:
+  $ scoper completion zsh > "${fpath[1]}/_scoper"
+
+Fish:
+  $ scoper completion fish | source
+
+PowerShell:
+  PS> scoper completion powershell | Out-String | Invoke-Expression
+`,
+	DisableFlagsInUseLine: true,
+	ValidArgs:             []string{"bash", "zsh", "fish", "powershell"},
+	Args:                  cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+	// Generating completions doesn't need a scope, unlike most of the root
+	// command's other subcommands.
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error { return nil },
+	RunE: func(c *cobra.Command, args []string) error {
+		switch args[0] {
+		case "bash":
+			return c.Root().GenBashCompletion(os.Stdout)
+		case "zsh":
+			return c.Root().GenZshCompletion(os.Stdout)
+		case "fish":
+			return c.Root().GenFishCompletion(os.Stdout, true)
+		case "powershell":
+			return c.Root().GenPowerShellCompletionWithDesc(os.Stdout)
+		}
+
+		return fmt.Errorf("unsupported shell: %q", args[0])
+	},
+}
+
+func init() {
+	cmd.CompletionOptions.DisableDefaultCmd = true
+	cmd.AddCommand(completionCmd)
+
+	cmd.RegisterFlagCompletionFunc("range", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	})
+
+	cmd.RegisterFlagCompletionFunc("range-file", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return nil, cobra.ShellCompDirectiveDefault
+	})
+
+	cmd.RegisterFlagCompletionFunc("scope", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		profiles := viper.GetStringMapStringSlice("scopes")
+
+		names := make([]string, 0, len(profiles))
+		for name := range profiles {
+			names = append(names, name)
+		}
+
+		return names, cobra.ShellCompDirectiveNoFileComp
+	})
+
+	filterCmd.ValidArgsFunction = completeFiles
+	countCmd.ValidArgsFunction = completeFiles
+	extractCmd.ValidArgsFunction = completeFiles
+}
+
+// completeFiles lets the shell fall back to its own filename completion
+// for positional file arguments.
+func completeFiles(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return nil, cobra.ShellCompDirectiveDefault
+}
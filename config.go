@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/russtone/iprange"
+)
+
+var (
+	cfgFile    string   // path to config file from "-C/--config" arg
+	scopeNames []string // names of scope profiles from "--scope" args
+)
+
+func init() {
+	cmd.PersistentFlags().StringVarP(&cfgFile, "config", "C", "", "config file (default search: ./scoper.yaml,\n"+
+		"$XDG_CONFIG_HOME/scoper/config.yaml, $HOME/.scoper.yaml)")
+	cmd.PersistentFlags().StringArrayVar(&scopeNames, "scope", []string{}, "named scope profile loaded from the config file,\n"+
+		"can be repeated",
+	)
+
+	cobra.OnInitialize(initConfig)
+}
+
+// initConfig loads the config file, either the one given via "-C/--config"
+// or, following viper conventions, the first of the default candidates
+// that exists and can be read; a candidate that fails to read is skipped.
+func initConfig() {
+	if cfgFile != "" {
+		viper.SetConfigFile(cfgFile)
+
+		if err := viper.ReadInConfig(); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %s\n", err)
+		}
+
+		return
+	}
+
+	candidates := []string{"scoper.yaml"}
+
+	if dir, err := os.UserConfigDir(); err == nil {
+		candidates = append(candidates, filepath.Join(dir, "scoper", "config.yaml"))
+	}
+
+	if home, err := os.UserHomeDir(); err == nil {
+		candidates = append(candidates, filepath.Join(home, ".scoper.yaml"))
+	}
+
+	for _, candidate := range candidates {
+		if _, err := os.Stat(candidate); err != nil {
+			continue
+		}
+
+		viper.SetConfigFile(candidate)
+
+		if err := viper.ReadInConfig(); err == nil {
+			return
+		}
+	}
+}
+
+// scopeProfile returns the IP range strings configured for the named scope
+// profile loaded from the config file.
+func scopeProfile(name string) ([]string, error) {
+	profiles := viper.GetStringMapStringSlice("scopes")
+
+	ranges, ok := profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("no such scope: %q", name)
+	}
+
+	return ranges, nil
+}
+
+// resolveScopeNames parses the IP ranges of every named scope profile into
+// a single iprange.Ranges, along with the original range strings.
+func resolveScopeNames(names []string) (iprange.Ranges, []string, error) {
+	result := make(iprange.Ranges, 0)
+	sources := make([]string, 0)
+
+	for _, name := range names {
+		ranges, err := scopeProfile(name)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for _, s := range ranges {
+			r := iprange.Parse(s)
+			if r == nil {
+				return nil, nil, fmt.Errorf("invalid IP range %q in scope %q", s, name)
+			}
+			result = append(result, r)
+			sources = append(sources, s)
+		}
+	}
+
+	return result, sources, nil
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+
+	"github.com/spf13/cobra"
+
+	"github.com/russtone/ipsearch"
+)
+
+// countCmd reports per-input statistics about how many lines and IP
+// addresses matched scope versus how many did not.
+var countCmd = &cobra.Command{
+	Use:   "count [files...]",
+	Short: "Count lines and IP addresses matching and not matching scope",
+	Args:  cobra.ArbitraryArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		inputs, err := openInputs(args)
+		if err != nil {
+			return err
+		}
+		defer closeInputs(inputs)
+
+		for _, input := range inputs {
+			var linesMatched, linesTotal int
+			var ipsMatched, ipsTotal int
+
+			scanner := bufio.NewScanner(input)
+
+			for scanner.Scan() {
+				linesTotal++
+				lineMatched := false
+
+				for _, r := range ipsearch.Find(scanner.Text()) {
+					ip := net.ParseIP(r)
+					if ip == nil {
+						continue
+					}
+
+					ipsTotal++
+
+					if scope.Contains(ip) && !exclude.Contains(ip) {
+						ipsMatched++
+						lineMatched = true
+					}
+				}
+
+				if lineMatched {
+					linesMatched++
+				}
+			}
+
+			if err := scanner.Err(); err != nil {
+				return err
+			}
+
+			fmt.Printf("%s: %d/%d lines matched, %d/%d IP addresses matched\n",
+				input.Name(), linesMatched, linesTotal, ipsMatched, ipsTotal)
+		}
+
+		return nil
+	},
+}
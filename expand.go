@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/spf13/cobra"
+)
+
+// maxExpand caps how many addresses "expand" will print, mainly to guard
+// against accidentally enumerating a huge IPv6 range.
+var maxExpand int
+
+func init() {
+	expandCmd.Flags().IntVar(&maxExpand, "max", 1000000, "maximum number of IP addresses to enumerate (safety cap, mostly for IPv6 ranges)")
+}
+
+// expandCmd enumerates every IP address covered by scope, skipping
+// addresses that fall in exclude.
+var expandCmd = &cobra.Command{
+	Use:   "expand",
+	Short: "Enumerate every IP address in scope",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		count := 0
+
+		for _, r := range scope {
+			it := r.Iterator()
+
+			var ip net.IP
+			for it.Next(&ip) {
+				if exclude.Contains(ip) {
+					continue
+				}
+
+				if count >= maxExpand {
+					return fmt.Errorf("reached --max limit of %d addresses, scope has more", maxExpand)
+				}
+
+				fmt.Println(ip)
+				count++
+			}
+		}
+
+		return nil
+	},
+}
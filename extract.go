@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+
+	"github.com/spf13/cobra"
+
+	"github.com/russtone/ipsearch"
+)
+
+var withLine bool // "-l/--with-line" arg
+
+func init() {
+	extractCmd.Flags().BoolVarP(&withLine, "with-line", "l", false, "print the source file and line number together with each IP address")
+}
+
+// extractCmd prints the deduplicated set of in scope IP addresses found in
+// the inputs.
+var extractCmd = &cobra.Command{
+	Use:   "extract [files...]",
+	Short: "Print deduplicated in scope IP addresses",
+	Args:  cobra.ArbitraryArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		inputs, err := openInputs(args)
+		if err != nil {
+			return err
+		}
+		defer closeInputs(inputs)
+
+		seen := make(map[string]bool)
+
+		for _, input := range inputs {
+			scanner := bufio.NewScanner(input)
+			lineNum := 0
+
+			for scanner.Scan() {
+				lineNum++
+
+				for _, r := range ipsearch.Find(scanner.Text()) {
+					ip := net.ParseIP(r)
+					if ip == nil {
+						continue
+					}
+
+					if !scope.Contains(ip) || exclude.Contains(ip) {
+						continue
+					}
+
+					addr := ip.String()
+					if seen[addr] {
+						continue
+					}
+					seen[addr] = true
+
+					if withLine {
+						fmt.Printf("%s:%d:%s\n", input.Name(), lineNum, addr)
+					} else {
+						fmt.Println(addr)
+					}
+				}
+			}
+
+			if err := scanner.Err(); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	},
+}
@@ -0,0 +1,396 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"container/heap"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/russtone/ipsearch"
+)
+
+var (
+	invertMatch  bool   // invert match mode, "-v" arg
+	colors       bool   // color mode, "-c" arg
+	jobs         int    // number of worker goroutines, "-j/--jobs" arg
+	unordered    bool   // skip the per-input ordering heap, "--unordered" arg
+	outputFormat string // output format, "-o/--output" arg
+)
+
+func init() {
+	filterCmd.Flags().BoolVarP(&invertMatch, "invert-match", "v", false, "invert match: show lines with no IP address from scope")
+	filterCmd.Flags().BoolVarP(&colors, "color", "c", false, "color mode: print all lines, but highlight in scope IP\n"+
+		"addresses with green, excluded with yellow and rest with red")
+	filterCmd.Flags().IntVarP(&jobs, "jobs", "j", runtime.NumCPU(), "number of worker goroutines classifying lines")
+	filterCmd.Flags().BoolVar(&unordered, "unordered", false, "print lines as soon as they are ready, without\n"+
+		"preserving input order",
+	)
+	filterCmd.Flags().StringVarP(&outputFormat, "output", "o", "text", "output format: text, json, jsonl or csv")
+}
+
+// filterCmd prints lines containing IP addresses from scope. It is the
+// original behavior of the root command before subcommands were introduced.
+var filterCmd = &cobra.Command{
+	Use:   "filter [files...]",
+	Short: "Print lines containing IP addresses from scope",
+	Args:  cobra.ArbitraryArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		switch outputFormat {
+		case "text", "json", "jsonl", "csv":
+		default:
+			return fmt.Errorf("invalid output format: %q", outputFormat)
+		}
+
+		if jobs < 1 {
+			return fmt.Errorf("invalid --jobs: %d (must be >= 1)", jobs)
+		}
+
+		inputs, err := openInputs(args)
+		if err != nil {
+			return err
+		}
+		defer closeInputs(inputs)
+
+		return runFilter(inputs)
+	},
+}
+
+// line is a single line read from one of the inputs, tagged with its
+// position so output can be restored to input order.
+type line struct {
+	inputIdx int
+	lineNum  int
+	file     string
+	text     string
+}
+
+// matchEntry describes a single IP address found in a line.
+type matchEntry struct {
+	IP      string `json:"ip"`
+	InScope bool   `json:"in_scope"`
+	Range   string `json:"range,omitempty"`
+}
+
+// result is a line after it has been classified and, in color mode,
+// colorized by a worker.
+type result struct {
+	inputIdx int
+	lineNum  int
+	file     string
+	text     string
+	show     bool
+	matches  []matchEntry
+}
+
+// jsonlRecord is the shape of a single record in "-o json"/"-o jsonl".
+type jsonlRecord struct {
+	File    string       `json:"file"`
+	Line    int          `json:"line"`
+	Text    string       `json:"text"`
+	Matches []matchEntry `json:"matches"`
+}
+
+func toJSONLRecord(r result) jsonlRecord {
+	return jsonlRecord{File: r.file, Line: r.lineNum, Text: r.text, Matches: r.matches}
+}
+
+// lineBufPool reuses the buffers used to build colorized lines so the hot
+// path does not allocate one per matched IP address.
+var lineBufPool = sync.Pool{
+	New: func() interface{} { return new(bytes.Buffer) },
+}
+
+// runFilter reads all inputs through a producer/worker-pool pipeline: one
+// goroutine reads lines into a bounded channel, jobs workers classify and
+// colorize them, and a single writer prints the results, restoring input
+// order unless --unordered was given.
+func runFilter(inputs []*os.File) error {
+	lines := make(chan line, jobs*4)
+	results := make(chan result, jobs*4)
+
+	var readErr error
+
+	go func() {
+		defer close(lines)
+
+		for idx, input := range inputs {
+			scanner := bufio.NewScanner(input)
+			lineNum := 0
+
+			for scanner.Scan() {
+				lineNum++
+				lines <- line{inputIdx: idx, lineNum: lineNum, file: input.Name(), text: scanner.Text()}
+			}
+
+			if err := scanner.Err(); err != nil {
+				readErr = err
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	wg.Add(jobs)
+
+	for i := 0; i < jobs; i++ {
+		go func() {
+			defer wg.Done()
+			filterWorker(lines, results)
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	var jsonRecords []jsonlRecord
+	var csvWriter *csv.Writer
+	csvHeaderWritten := false
+
+	emit := func(r result) {
+		show := shouldShow(r.show)
+
+		switch outputFormat {
+		case "json":
+			if show {
+				jsonRecords = append(jsonRecords, toJSONLRecord(r))
+			}
+		case "jsonl":
+			if show {
+				printJSONL(toJSONLRecord(r))
+			}
+		case "csv":
+			if show {
+				if csvWriter == nil {
+					csvWriter = csv.NewWriter(os.Stdout)
+				}
+				printCSV(csvWriter, &csvHeaderWritten, toJSONLRecord(r))
+			}
+		default:
+			if colors || show {
+				fmt.Println(r.text)
+			}
+		}
+	}
+
+	if unordered {
+		for r := range results {
+			emit(r)
+		}
+	} else {
+		writeOrdered(results, len(inputs), emit)
+	}
+
+	if csvWriter != nil {
+		csvWriter.Flush()
+	}
+
+	if outputFormat == "json" {
+		b, err := json.MarshalIndent(jsonRecords, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(b))
+	}
+
+	return readErr
+}
+
+// printJSONL prints a single JSON Lines record.
+func printJSONL(rec jsonlRecord) {
+	b, err := json.Marshal(rec)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %s\n", err)
+		return
+	}
+
+	fmt.Println(string(b))
+}
+
+// printCSV flattens a record into one CSV row per matched IP address,
+// writing a header the first time it is called.
+func printCSV(w *csv.Writer, headerWritten *bool, rec jsonlRecord) {
+	if !*headerWritten {
+		w.Write([]string{"file", "line", "text", "ip", "in_scope", "range"})
+		*headerWritten = true
+	}
+
+	for _, m := range rec.Matches {
+		w.Write([]string{
+			rec.File,
+			strconv.Itoa(rec.Line),
+			rec.Text,
+			m.IP,
+			strconv.FormatBool(m.InScope),
+			m.Range,
+		})
+	}
+
+	w.Flush()
+}
+
+// effectiveMatch reports whether ip counts as an in-scope match: it must be
+// covered by scope and not also covered by exclude.
+func effectiveMatch(ip net.IP) bool {
+	return scope.Contains(ip) && !exclude.Contains(ip)
+}
+
+// shouldShow applies --invert-match to whether a line matched.
+func shouldShow(match bool) bool {
+	return match != invertMatch
+}
+
+// matchingRange returns the original source string of the first range in
+// scope that contains ip, or "" if none does. iprange.Ranges doesn't expose
+// which range matched (and iprange.Range has no Stringer), so this looks
+// the source string up by index in scopeSources, which parseRanges keeps
+// parallel to scope.
+func matchingRange(ip net.IP) string {
+	for i, r := range scope {
+		if r.Contains(ip) {
+			return scopeSources[i]
+		}
+	}
+
+	return ""
+}
+
+// filterWorker classifies lines against scope/exclude and, in color mode,
+// rewrites matched IP addresses with their highlight color. The IP
+// addresses found in a line are collected into a per-worker slice that is
+// reused across lines instead of growing a fresh one each time.
+func filterWorker(lines <-chan line, results chan<- result) {
+	green := color.New(color.FgGreen).Add(color.Bold)
+	yellow := color.New(color.FgYellow).Add(color.Bold)
+	red := color.New(color.FgRed).Add(color.Bold)
+
+	ips := make([]net.IP, 0, 8)
+
+	for l := range lines {
+		text := l.text
+		match := false
+		var matches []matchEntry
+
+		ips = ips[:0]
+		for _, r := range ipsearch.Find(text) {
+			if ip := net.ParseIP(r); ip != nil {
+				ips = append(ips, ip)
+			}
+		}
+
+		for _, ip := range ips {
+			excluded := exclude.Contains(ip)
+			inScope := effectiveMatch(ip)
+
+			entry := matchEntry{IP: ip.String(), InScope: inScope}
+			if entry.InScope {
+				entry.Range = matchingRange(ip)
+				match = true
+			}
+			matches = append(matches, entry)
+
+			if colors {
+				// In colors mode highlight excluded IP with yellow color,
+				// in scope IP with green and rest with red.
+				var colorized string
+
+				switch {
+				case excluded:
+					colorized = yellow.Sprintf("%s", ip)
+				case inScope:
+					colorized = green.Sprintf("%s", ip)
+				default:
+					colorized = red.Sprintf("%s", ip)
+				}
+
+				text = replaceIP(text, ip.String(), colorized)
+			}
+		}
+
+		results <- result{
+			inputIdx: l.inputIdx,
+			lineNum:  l.lineNum,
+			file:     l.file,
+			text:     text,
+			show:     match,
+			matches:  matches,
+		}
+	}
+}
+
+// replaceIP replaces every occurrence of ip in line with colorized, using a
+// pooled buffer instead of repeated calls to strings.Replace.
+func replaceIP(line, ip, colorized string) string {
+	if !strings.Contains(line, ip) {
+		return line
+	}
+
+	buf := lineBufPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	defer lineBufPool.Put(buf)
+
+	rest := line
+	for {
+		i := strings.Index(rest, ip)
+		if i < 0 {
+			buf.WriteString(rest)
+			break
+		}
+
+		buf.WriteString(rest[:i])
+		buf.WriteString(colorized)
+		rest = rest[i+len(ip):]
+	}
+
+	return buf.String()
+}
+
+// resultHeap is a min-heap of results ordered by line number, one per
+// input, used to restore input order even though workers finish out of
+// order.
+type resultHeap []result
+
+func (h resultHeap) Len() int           { return len(h) }
+func (h resultHeap) Less(i, j int) bool { return h[i].lineNum < h[j].lineNum }
+func (h resultHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *resultHeap) Push(x interface{}) { *h = append(*h, x.(result)) }
+
+func (h *resultHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[:n-1]
+	return x
+}
+
+// writeOrdered drains results, calling emit for every result (matching or
+// not) in the same order it appeared in its input.
+func writeOrdered(results <-chan result, numInputs int, emit func(result)) {
+	heaps := make([]resultHeap, numInputs)
+	next := make([]int, numInputs)
+	for i := range next {
+		next[i] = 1
+	}
+
+	for r := range results {
+		heap.Push(&heaps[r.inputIdx], r)
+
+		for len(heaps[r.inputIdx]) > 0 && heaps[r.inputIdx][0].lineNum == next[r.inputIdx] {
+			top := heap.Pop(&heaps[r.inputIdx]).(result)
+			next[r.inputIdx]++
+			emit(top)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/russtone/iprange"
+)
+
+// withScope runs fn with scope/exclude set to the parsed ranges, restoring
+// the previous package-level values afterward.
+func withScope(t *testing.T, scopeRanges, excludeRanges []string, fn func()) {
+	t.Helper()
+
+	origScope, origExclude := scope, exclude
+	defer func() { scope, exclude = origScope, origExclude }()
+
+	scope = make(iprange.Ranges, 0, len(scopeRanges))
+	for _, s := range scopeRanges {
+		scope = append(scope, iprange.Parse(s))
+	}
+
+	exclude = make(iprange.Ranges, 0, len(excludeRanges))
+	for _, s := range excludeRanges {
+		exclude = append(exclude, iprange.Parse(s))
+	}
+
+	fn()
+}
+
+func TestEffectiveMatch(t *testing.T) {
+	tests := []struct {
+		name string
+		ip   string
+		want bool
+	}{
+		{"in scope, not excluded", "192.168.1.10", true},
+		{"in scope, excluded", "192.168.1.5", false},
+		{"out of scope", "10.0.0.1", false},
+	}
+
+	withScope(t, []string{"192.168.1.0/24"}, []string{"192.168.1.5"}, func() {
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				got := effectiveMatch(net.ParseIP(tt.ip))
+				if got != tt.want {
+					t.Errorf("effectiveMatch(%s) = %v, want %v", tt.ip, got, tt.want)
+				}
+			})
+		}
+	})
+}
+
+func TestShouldShow(t *testing.T) {
+	tests := []struct {
+		name        string
+		match       bool
+		invertMatch bool
+		want        bool
+	}{
+		{"match, not inverted", true, false, true},
+		{"no match, not inverted", false, false, false},
+		{"match, inverted", true, true, false},
+		{"no match, inverted", false, true, true},
+	}
+
+	origInvertMatch := invertMatch
+	defer func() { invertMatch = origInvertMatch }()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			invertMatch = tt.invertMatch
+
+			if got := shouldShow(tt.match); got != tt.want {
+				t.Errorf("shouldShow(%v) = %v, want %v", tt.match, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestWriteOrdered feeds results for two inputs out of line-number order and
+// checks that each input's lines are emitted in ascending order regardless
+// of the order they arrive on the channel.
+func TestWriteOrdered(t *testing.T) {
+	results := make(chan result, 10)
+
+	results <- result{inputIdx: 0, lineNum: 2, text: "a2"}
+	results <- result{inputIdx: 1, lineNum: 1, text: "b1"}
+	results <- result{inputIdx: 0, lineNum: 1, text: "a1"}
+	results <- result{inputIdx: 1, lineNum: 3, text: "b3"}
+	results <- result{inputIdx: 0, lineNum: 3, text: "a3"}
+	results <- result{inputIdx: 1, lineNum: 2, text: "b2"}
+	close(results)
+
+	emitted := make(map[int][]string)
+	writeOrdered(results, 2, func(r result) {
+		emitted[r.inputIdx] = append(emitted[r.inputIdx], r.text)
+	})
+
+	want := map[int][]string{
+		0: {"a1", "a2", "a3"},
+		1: {"b1", "b2", "b3"},
+	}
+
+	if !reflect.DeepEqual(emitted, want) {
+		t.Errorf("writeOrdered emitted %v, want %v", emitted, want)
+	}
+}
+
+func TestToJSONLRecord(t *testing.T) {
+	r := result{
+		file:    "in.txt",
+		lineNum: 3,
+		text:    "192.168.1.10 is up",
+		matches: []matchEntry{
+			{IP: "192.168.1.10", InScope: true, Range: "192.168.1.0/24"},
+		},
+	}
+
+	got := toJSONLRecord(r)
+	want := jsonlRecord{
+		File:    "in.txt",
+		Line:    3,
+		Text:    "192.168.1.10 is up",
+		Matches: r.matches,
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("toJSONLRecord(%+v) = %+v, want %+v", r, got, want)
+	}
+}
+
+func TestPrintCSV(t *testing.T) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	headerWritten := false
+
+	printCSV(w, &headerWritten, jsonlRecord{
+		File: "in.txt",
+		Line: 1,
+		Text: "192.168.1.10 is up",
+		Matches: []matchEntry{
+			{IP: "192.168.1.10", InScope: true, Range: "192.168.1.0/24"},
+		},
+	})
+	printCSV(w, &headerWritten, jsonlRecord{
+		File: "in.txt",
+		Line: 2,
+		Text: "10.0.0.1 is down",
+		Matches: []matchEntry{
+			{IP: "10.0.0.1", InScope: false},
+		},
+	})
+
+	want := "file,line,text,ip,in_scope,range\n" +
+		"in.txt,1,192.168.1.10 is up,192.168.1.10,true,192.168.1.0/24\n" +
+		"in.txt,2,10.0.0.1 is down,10.0.0.1,false,\n"
+
+	if got := buf.String(); got != want {
+		t.Errorf("printCSV wrote %q, want %q", got, want)
+	}
+}
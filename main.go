@@ -3,24 +3,22 @@ package main
 import (
 	"bufio"
 	"fmt"
-	"net"
 	"os"
-	"strings"
 
-	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 
 	"github.com/russtone/iprange"
-	"github.com/russtone/ipsearch"
 )
 
 var (
-	ranges    []string // slice of IP ranges from "-r" args
-	rangeFile string   // path to files with ranges from "-R" arg
-	colors    bool     // color mode, "-c" arg
-
-	scope  iprange.Ranges // all ranges combined
-	inputs []*os.File     // all inputs combined
+	ranges      []string // slice of IP ranges from "-r" args
+	rangeFile   string   // path to files with ranges from "-R" arg
+	excludes    []string // slice of IP ranges to exclude from "-x" args
+	excludeFile string   // path to file with ranges to exclude from "-X" arg
+
+	scope        iprange.Ranges // all ranges combined
+	scopeSources []string       // original range strings, parallel to scope
+	exclude      iprange.Ranges // all excluded ranges combined
 )
 
 func init() {
@@ -36,151 +34,139 @@ func init() {
 		"- fe80::1:2:3:1,2,4-a",
 	)
 	cmd.PersistentFlags().StringVarP(&rangeFile, "range-file", "R", "", "path to file with IP ranges on each line")
-	cmd.PersistentFlags().BoolVarP(&colors, "color", "c", false, "color mode: print all lines,\n"+
-		"but highlight in scope IP addresses with green and rest with red")
+	cmd.PersistentFlags().StringArrayVarP(&excludes, "exclude", "x", []string{}, "IP range to exclude from scope.\n"+
+		"Same format as \"-r\".",
+	)
+	cmd.PersistentFlags().StringVarP(&excludeFile, "exclude-file", "X", "", "path to file with IP ranges to exclude on each line")
+
+	cmd.AddCommand(filterCmd, countCmd, extractCmd, expandCmd, checkCmd, scopesCmd)
 }
 
-func main() {
-	if err := cmd.Execute(); err != nil {
-		fmt.Fprintf(os.Stderr, "error: %s\n", err)
+// parseRanges parses IP ranges from a slice of strings and optionally from
+// a file with one range per line, returning them combined along with the
+// original strings they were parsed from (iprange.Range has no Stringer,
+// so callers that need to display a range back to the user need this).
+func parseRanges(values []string, file string) (iprange.Ranges, []string, error) {
+	result := make(iprange.Ranges, 0)
+	sources := make([]string, 0)
+
+	for _, s := range values {
+		r := iprange.Parse(s)
+		if r == nil {
+			return nil, nil, fmt.Errorf("invalid IP range: %q", s)
+		}
+		result = append(result, r)
+		sources = append(sources, s)
 	}
-}
 
-// cmd represents the base command when called without any subcommands.
-var cmd = &cobra.Command{
-	Use:           "scoper",
-	Short:         "Filters lines containing IP addresses from scope",
-	SilenceErrors: true,
-	SilenceUsage:  true,
-	Args:          cobra.ArbitraryArgs,
-	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
-		scope = make(iprange.Ranges, 0)
+	if file != "" {
+		if _, err := os.Stat(file); os.IsNotExist(err) {
+			return nil, nil, fmt.Errorf("no such file: %q", file)
+		}
 
-		// IP ranges from command line arguments.
-		for _, s := range ranges {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, nil, fmt.Errorf("fail to open file: %q", file)
+		}
+		defer f.Close()
+
+		scanner := bufio.NewScanner(f)
+
+		for scanner.Scan() {
+			s := scanner.Text()
 			r := iprange.Parse(s)
 			if r == nil {
-				return fmt.Errorf("invalid IP range: %q", s)
+				return nil, nil, fmt.Errorf("invalid IP range: %q", s)
 			}
-			scope = append(scope, r)
+			result = append(result, r)
+			sources = append(sources, s)
 		}
 
-		// IP ranges from file.
-		if rangeFile != "" {
-			if _, err := os.Stat(rangeFile); os.IsNotExist(err) {
-				return fmt.Errorf("no such file: %q", rangeFile)
-			}
+		if err := scanner.Err(); err != nil {
+			return nil, nil, err
+		}
+	}
 
-			rf, err := os.Open(rangeFile)
-			if err != nil {
-				return fmt.Errorf("fail to open file: %q", rangeFile)
-			}
-			defer rf.Close()
+	return result, sources, nil
+}
 
-			scanner := bufio.NewScanner(rf)
+// openInputs opens stdin (when piped) and every positional file argument,
+// shared by the subcommands that read lines from input files.
+func openInputs(args []string) ([]*os.File, error) {
+	var inputs []*os.File
 
-			for scanner.Scan() {
-				s := scanner.Text()
-				r := iprange.Parse(scanner.Text())
-				if r == nil {
-					return fmt.Errorf("invalid IP range: %q", s)
-				}
-				scope = append(scope, r)
-			}
+	// Check if there is some data in the stdin.
+	stat, _ := os.Stdin.Stat()
+	if (stat.Mode() & os.ModeCharDevice) == 0 {
+		inputs = append(inputs, os.Stdin)
+	}
 
-			if err := scanner.Err(); err != nil {
-				return err
-			}
+	for _, fpath := range args {
+		if _, err := os.Stat(fpath); os.IsNotExist(err) {
+			return nil, fmt.Errorf("no such file: %q", fpath)
 		}
 
-		if len(scope) == 0 {
-			return fmt.Errorf("empty scope")
+		file, err := os.Open(fpath)
+		if err != nil {
+			return nil, fmt.Errorf("fail to open file: %q", fpath)
 		}
 
-		// Check if there is some data in the stdin.
-		stat, _ := os.Stdin.Stat()
-		if (stat.Mode() & os.ModeCharDevice) == 0 {
-			inputs = append(inputs, os.Stdin)
-		}
+		inputs = append(inputs, file)
+	}
 
-		for _, fpath := range args {
-			if _, err := os.Stat(fpath); os.IsNotExist(err) {
-				return fmt.Errorf("no such file: %q", fpath)
-			}
+	if len(inputs) == 0 {
+		return nil, fmt.Errorf("no inputs")
+	}
 
-			file, err := os.Open(fpath)
-			if err != nil {
-				return fmt.Errorf("fail to open file: %q", fpath)
-			}
+	return inputs, nil
+}
 
-			inputs = append(inputs, file)
+// closeInputs closes every input opened by openInputs, except stdin.
+func closeInputs(inputs []*os.File) {
+	for _, input := range inputs {
+		if input != os.Stdin {
+			input.Close()
 		}
+	}
+}
+
+func main() {
+	if err := cmd.Execute(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+// cmd represents the base command when called without any subcommands.
+var cmd = &cobra.Command{
+	Use:           "scoper",
+	Short:         "Inspect and filter IP addresses against a scope",
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		var err error
 
-		if len(inputs) == 0 {
-			return fmt.Errorf("no inputs")
+		scope, scopeSources, err = parseRanges(ranges, rangeFile)
+		if err != nil {
+			return err
 		}
 
-		return nil
-	},
-	Run: func(cmd *cobra.Command, args []string) {
-		green := color.New(color.FgGreen).Add(color.Bold)
-		red := color.New(color.FgRed).Add(color.Bold)
-
-		// Loop through all inputs.
-		for _, input := range inputs {
-			scanner := bufio.NewScanner(input)
-
-			// Loop through input lines.
-			for scanner.Scan() {
-				line := scanner.Text()
-				show := false
-
-				// Loop through all IP addresses in line.
-				for _, r := range ipsearch.Find(line) {
-					ip := net.ParseIP(r)
-					if ip == nil {
-						continue
-					}
-
-					if colors {
-						// In colors mode highlight IP in scope with green color
-						// and rest with red.
-						var colorized string
-
-						if scope.Contains(ip) {
-							colorized = green.Sprintf("%s", ip)
-						} else {
-							colorized = red.Sprintf("%s", ip)
-						}
-
-						// Replace IP in line with colorized IP.
-						line = strings.Replace(line, ip.String(), colorized, -1)
-
-					} else {
-						// In normal mode print only lines containing IP from scope.
-						if scope.Contains(ip) {
-							show = true
-							break
-						}
-					}
-				}
-
-				// Print line if color mode or line contains IP from scope.
-				if colors || show {
-					fmt.Println(line)
-				}
-			}
+		namedRanges, namedSources, err := resolveScopeNames(scopeNames)
+		if err != nil {
+			return err
+		}
+		scope = append(scope, namedRanges...)
+		scopeSources = append(scopeSources, namedSources...)
 
-			if err := scanner.Err(); err != nil {
-				fmt.Fprintf(os.Stderr, "error: %s\n", err)
-			}
+		if len(scope) == 0 {
+			return fmt.Errorf("empty scope")
 		}
-	},
-	PersistentPostRun: func(cmd *cobra.Command, args []string) {
-		for _, input := range inputs {
-			if input != os.Stdin {
-				input.Close()
-			}
+
+		exclude, _, err = parseRanges(excludes, excludeFile)
+		if err != nil {
+			return err
 		}
+
+		return nil
 	},
 }
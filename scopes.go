@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// scopesCmd groups subcommands for inspecting named scope profiles loaded
+// from the config file. It does not require "-r/-R" to be set, so it
+// overrides the root command's PersistentPreRunE.
+var scopesCmd = &cobra.Command{
+	Use:               "scopes",
+	Short:             "Inspect named scope profiles loaded from the config file",
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error { return nil },
+}
+
+var scopesListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the names of the scope profiles loaded from the config file",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		profiles := viper.GetStringMapStringSlice("scopes")
+
+		names := make([]string, 0, len(profiles))
+		for name := range profiles {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			fmt.Println(name)
+		}
+
+		return nil
+	},
+}
+
+var scopesShowCmd = &cobra.Command{
+	Use:   "show <name>",
+	Short: "Print the IP ranges of a named scope profile",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ranges, err := scopeProfile(args[0])
+		if err != nil {
+			return err
+		}
+
+		for _, r := range ranges {
+			fmt.Println(r)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	scopesCmd.AddCommand(scopesListCmd, scopesShowCmd)
+}